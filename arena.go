@@ -0,0 +1,93 @@
+package rueidis
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DisableMessagePool turns the RedisMessage arena below into a no-op, so
+// every array reply keeps allocating its own []RedisMessage as before.
+// Set it for code that hands a RedisMessage/RedisResult to another
+// goroutine and needs it to stay valid after the original caller is done
+// with it, since Release (and therefore the pool) is not safe to use once
+// that happens. It is read without synchronization on every parsed reply,
+// so set it once during startup, before any client begins parsing, not
+// concurrently with traffic.
+var DisableMessagePool = false
+
+// messageArena backs the nested []RedisMessage slices of a single reply
+// tree with one contiguous, pooled slab instead of one allocation per
+// array node. It is acquired once per top-level reply and returned to
+// arenaPool by RedisMessage.release/RedisResult.Release.
+type messageArena struct {
+	buf      []RedisMessage
+	released atomic.Bool
+}
+
+var arenaPool = sync.Pool{New: func() interface{} { return new(messageArena) }}
+
+func newMessageArena() *messageArena {
+	if DisableMessagePool {
+		return nil
+	}
+	a := arenaPool.Get().(*messageArena)
+	a.buf = a.buf[:0]
+	a.released.Store(false)
+	return a
+}
+
+// alloc carves out a contiguous []RedisMessage of length n from the arena,
+// growing its backing slab if needed, for a parser building one array/map
+// node's values. The slice is capped at length n with a full slice
+// expression so it cannot be grown in place by a stray append: append
+// growing a.buf itself may reallocate and copy, which is fine since every
+// node only ever indexes its own already-carved range, but without the cap
+// the returned slice's capacity would run to the end of a.buf's backing
+// array, i.e. into whatever the arena carves out next for a sibling node.
+func (a *messageArena) alloc(n int) []RedisMessage {
+	base := len(a.buf)
+	a.buf = append(a.buf, make([]RedisMessage, n)...)
+	return a.buf[base : base+n : base+n]
+}
+
+// release returns the message's arena, if any, to arenaPool. It is called
+// once per top-level reply; nested messages share the same arena and do
+// not need their own release.
+//
+// RedisResult.Release has a value receiver (so it keeps working on a
+// non-addressable RedisResult, e.g. client.Do(ctx, cmd).Release()), which
+// means a second Release call on the same RedisResult variable reaches
+// this method with the same, unmutated m.pool: it cannot tell from m alone
+// that the arena already went back to arenaPool. So the guard lives on the
+// arena itself instead: released is CAS'd true by whichever call gets
+// there first, and every other call (including ones arriving through
+// separate RedisResult copies) becomes a no-op.
+func (m *RedisMessage) release() {
+	if m.pool == nil || DisableMessagePool {
+		return
+	}
+	pool := m.pool
+	m.pool = nil
+	pool.release()
+}
+
+func (a *messageArena) release() {
+	if !a.released.CompareAndSwap(false, true) {
+		return
+	}
+	a.buf = a.buf[:0]
+	arenaPool.Put(a)
+}
+
+// Release returns the reply tree backing this result to the message pool.
+// AsStrSlice copies its strings out, and AsMap copies each value message out
+// (a nested array/map inside such a value still shares the arena). ToArray
+// returns the reply's own slice. Callers must copy what they need from
+// shared data before calling Release, since the backing storage may be
+// reused afterwards.
+//
+// Release is safe to call more than once on the same result (or on copies
+// of it): only the first call actually returns the arena to the pool.
+func (r RedisResult) Release() {
+	r.val.release()
+}
@@ -0,0 +1,131 @@
+package rueidis
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestArenaAllocCapsSliceAgainstSiblingCorruption(t *testing.T) {
+	a := newMessageArena()
+	first := a.alloc(2)
+	second := a.alloc(2)
+	second[0] = RedisMessage{typ: '$', string: "untouched"}
+
+	first = append(first, RedisMessage{typ: '$', string: "leaked"}, RedisMessage{typ: '$', string: "leaked"})
+	if second[0].string != "untouched" {
+		t.Fatalf("append on an earlier arena slice corrupted a sibling: got %q", second[0].string)
+	}
+	_ = first
+}
+
+func TestReadRESPPopulatesPool(t *testing.T) {
+	src := RedisMessage{typ: '*', values: []RedisMessage{{typ: '$', string: "a"}, {typ: ':', integer: 1}}}
+	buf := &bytes.Buffer{}
+	src.WriteRESP(buf)
+	got, err := ReadRESP(bufio.NewReader(buf))
+	if err != nil {
+		t.Fatalf("ReadRESP: %v", err)
+	}
+	if got.pool == nil {
+		t.Fatalf("expected ReadRESP to populate the top-level message's arena pool")
+	}
+	got.release()
+	if got.pool != nil {
+		t.Fatalf("expected release to clear the pool reference")
+	}
+}
+
+func TestRedisResultReleaseReturnsArenaToPool(t *testing.T) {
+	src := RedisMessage{typ: '*', values: []RedisMessage{{typ: '$', string: "a"}}}
+	buf := &bytes.Buffer{}
+	src.WriteRESP(buf)
+	got, err := ReadRESP(bufio.NewReader(buf))
+	if err != nil {
+		t.Fatalf("ReadRESP: %v", err)
+	}
+	res := newResult(got, nil)
+	res.Release() // must not panic; it releases res's own copy of the arena.
+	if !got.pool.released.Load() {
+		t.Fatalf("expected Release to return the arena to the pool")
+	}
+}
+
+func TestRedisResultDoubleReleaseDoesNotAliasArena(t *testing.T) {
+	src := RedisMessage{typ: '*', values: []RedisMessage{{typ: '$', string: "a"}}}
+	buf := &bytes.Buffer{}
+	src.WriteRESP(buf)
+	got, err := ReadRESP(bufio.NewReader(buf))
+	if err != nil {
+		t.Fatalf("ReadRESP: %v", err)
+	}
+	res := newResult(got, nil)
+
+	res.Release()
+	res.Release() // must be a no-op: the arena already went back once.
+
+	first := newMessageArena()
+	second := newMessageArena()
+	if first == second {
+		t.Fatalf("double Release() put the same arena back twice: two Get()s aliased %p", first)
+	}
+}
+
+func TestReadRESPWithDisableMessagePool(t *testing.T) {
+	DisableMessagePool = true
+	defer func() { DisableMessagePool = false }()
+
+	src := RedisMessage{typ: '*', values: []RedisMessage{{typ: '$', string: "a"}}}
+	buf := &bytes.Buffer{}
+	src.WriteRESP(buf)
+	got, err := ReadRESP(bufio.NewReader(buf))
+	if err != nil {
+		t.Fatalf("ReadRESP: %v", err)
+	}
+	if got.pool != nil {
+		t.Fatalf("expected no pool to be attached when DisableMessagePool is set")
+	}
+}
+
+func mgetReply(n int) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "*%d\r\n", n)
+	for i := 0; i < n; i++ {
+		v := strconv.Itoa(i)
+		fmt.Fprintf(buf, "$%d\r\n%s\r\n", len(v), v)
+	}
+	return buf.Bytes()
+}
+
+// BenchmarkReadRESPMGet10k_Pooled and BenchmarkReadRESPMGet10k_Unpooled
+// decode a 10k-element MGET-shaped array reply with the message arena on
+// and off, to show the allocation reduction the arena is meant to buy.
+func BenchmarkReadRESPMGet10k_Pooled(b *testing.B) {
+	raw := mgetReply(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m, err := ReadRESP(bufio.NewReader(bytes.NewReader(raw)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		res := newResult(m, nil)
+		res.Release()
+	}
+}
+
+func BenchmarkReadRESPMGet10k_Unpooled(b *testing.B) {
+	DisableMessagePool = true
+	defer func() { DisableMessagePool = false }()
+
+	raw := mgetReply(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadRESP(bufio.NewReader(bytes.NewReader(raw))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
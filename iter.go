@@ -0,0 +1,232 @@
+package rueidis
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+)
+
+// ArrayIter yields the elements of a RedisMessage array/set reply one at a
+// time instead of requiring the caller hold the whole []RedisMessage, so a
+// large SCAN/XRANGE/FT.SEARCH reply doesn't have to be copied out just to
+// be walked once.
+//
+// The underlying array is still fully materialized by the parser; ArrayIter
+// only avoids a second, caller-side copy of it. For a reply too large to
+// materialize at all, decode it straight off the connection with
+// ReadArrayIter/ReadMapIter instead.
+type ArrayIter struct {
+	values []RedisMessage
+	pos    int
+}
+
+// Next advances the iterator and reports whether a value is available.
+func (it *ArrayIter) Next() bool {
+	it.pos++
+	return it.pos <= len(it.values)
+}
+
+// Value returns the current element. It must only be called after a call
+// to Next that returned true.
+func (it *ArrayIter) Value() RedisMessage {
+	return it.values[it.pos-1]
+}
+
+// Len returns the number of elements remaining, including the current one
+// once Next has been called at least once. Before the first Next call
+// there is no current element yet, so Len reports the full count.
+func (it *ArrayIter) Len() int {
+	if it.pos == 0 {
+		return len(it.values)
+	}
+	return len(it.values) - it.pos + 1
+}
+
+// Iter returns an ArrayIter over the message's array/set elements.
+func (m *RedisMessage) Iter() (ArrayIter, error) {
+	values, err := m.ToArray()
+	if err != nil {
+		return ArrayIter{}, err
+	}
+	return ArrayIter{values: values}, nil
+}
+
+// IterArray delegates to RedisMessage.Iter, calling fn once per element in
+// order. It stops and returns the first error fn returns.
+func (r RedisResult) IterArray(fn func(RedisMessage) error) error {
+	if err := r.Error(); err != nil {
+		return err
+	}
+	it, err := r.val.Iter()
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		if err := fn(it.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IterMap walks a hash-like reply (RESP3 `%` map, or the RESP2 alternating
+// key/value array from commands such as HGETALL) one key/value pair at a
+// time, calling fn for each pair in order.
+func (r RedisResult) IterMap(fn func(k, v RedisMessage) error) error {
+	if err := r.Error(); err != nil {
+		return err
+	}
+	pairs, err := r.val.toPairs()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if err := fn(pairs[i], pairs[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamArrayIter decodes the elements of a RESP array/set reply one at a
+// time directly off a connection's bufio.Reader, via ReadArrayIter. Unlike
+// ArrayIter it never holds more than the current element in memory, so a
+// multi-million-entry SCAN/XRANGE/FT.SEARCH reply doesn't have to be
+// materialized in full to be walked once.
+type StreamArrayIter struct {
+	r *bufio.Reader
+	n int
+	i int
+}
+
+// ReadArrayIter reads a RESP array/set header (`*` or `~`) off r and
+// returns a StreamArrayIter that decodes each element on demand as Next
+// and Value are called.
+func ReadArrayIter(r *bufio.Reader) (StreamArrayIter, error) {
+	typ, body, err := readRESPHeader(r)
+	if err != nil {
+		return StreamArrayIter{}, err
+	}
+	if typ != '*' && typ != '~' {
+		return StreamArrayIter{}, fmt.Errorf("rueidis: ReadArrayIter expected an array/set, got type %q", typ)
+	}
+	n, err := strconv.Atoi(body)
+	if err != nil {
+		return StreamArrayIter{}, err
+	}
+	return StreamArrayIter{r: r, n: n}, nil
+}
+
+// Next reports whether a call to Value will decode another element.
+func (it *StreamArrayIter) Next() bool {
+	return it.i < it.n
+}
+
+// Value decodes and returns the next element off the connection. It must
+// only be called after a call to Next that returned true.
+func (it *StreamArrayIter) Value() (RedisMessage, error) {
+	it.i++
+	return ReadRESP(it.r)
+}
+
+// Len returns the number of elements not yet decoded by Value.
+func (it *StreamArrayIter) Len() int {
+	return it.n - it.i
+}
+
+// IterArrayRESP reads a RESP array/set off r and calls fn once per element
+// as it is decoded, without first collecting them into a []RedisMessage
+// the way RedisResult.IterArray does. This is the on-demand, connection-
+// layer counterpart to RedisMessage.Iter/IterArray, for replies too large
+// to hold in memory all at once.
+func IterArrayRESP(r *bufio.Reader, fn func(RedisMessage) error) error {
+	it, err := ReadArrayIter(r)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		v, err := it.Value()
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamPairIter is the streaming counterpart of StreamArrayIter for a
+// hash-like reply: a RESP3 `%` map, or the RESP2 alternating key/value
+// array returned by commands such as HGETALL.
+type StreamPairIter struct {
+	r *bufio.Reader
+	n int
+	i int
+}
+
+// ReadMapIter reads a hash-like reply header (`%`, `*`, or `~`) off r and
+// returns a StreamPairIter that decodes one key/value pair at a time as
+// Next and Value are called.
+func ReadMapIter(r *bufio.Reader) (StreamPairIter, error) {
+	typ, body, err := readRESPHeader(r)
+	if err != nil {
+		return StreamPairIter{}, err
+	}
+	n, err := strconv.Atoi(body)
+	if err != nil {
+		return StreamPairIter{}, err
+	}
+	switch typ {
+	case '%':
+		return StreamPairIter{r: r, n: n}, nil
+	case '*', '~':
+		if n%2 != 0 {
+			return StreamPairIter{}, fmt.Errorf("rueidis: ReadMapIter got an odd-length array reply")
+		}
+		return StreamPairIter{r: r, n: n / 2}, nil
+	default:
+		return StreamPairIter{}, fmt.Errorf("rueidis: ReadMapIter expected a map/array/set, got type %q", typ)
+	}
+}
+
+// Next reports whether a call to Value will decode another pair.
+func (it *StreamPairIter) Next() bool {
+	return it.i < it.n
+}
+
+// Value decodes and returns the next key/value pair off the connection. It
+// must only be called after a call to Next that returned true.
+func (it *StreamPairIter) Value() (k, v RedisMessage, err error) {
+	if k, err = ReadRESP(it.r); err != nil {
+		return
+	}
+	v, err = ReadRESP(it.r)
+	it.i++
+	return
+}
+
+// Len returns the number of pairs not yet decoded by Value.
+func (it *StreamPairIter) Len() int {
+	return it.n - it.i
+}
+
+// IterMapRESP reads a hash-like reply off r and calls fn once per key/value
+// pair as it is decoded, without first collecting the pairs into a flat
+// []RedisMessage the way RedisResult.IterMap does.
+func IterMapRESP(r *bufio.Reader, fn func(k, v RedisMessage) error) error {
+	it, err := ReadMapIter(r)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		k, v, err := it.Value()
+		if err != nil {
+			return err
+		}
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package rueidis
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestArrayIterLen(t *testing.T) {
+	m := RedisMessage{typ: '*', values: []RedisMessage{
+		{typ: ':', integer: 1}, {typ: ':', integer: 2}, {typ: ':', integer: 3},
+	}}
+	it, err := m.Iter()
+	if err != nil {
+		t.Fatalf("Iter: %v", err)
+	}
+	if l := it.Len(); l != 3 {
+		t.Fatalf("Len before first Next() = %d, want 3", l)
+	}
+	for i, want := range []int{3, 2, 1} {
+		if !it.Next() {
+			t.Fatalf("Next() = false at element %d", i)
+		}
+		if l := it.Len(); l != want {
+			t.Fatalf("Len() after Next() #%d = %d, want %d", i, l, want)
+		}
+	}
+	if it.Next() {
+		t.Fatalf("expected Next() = false after exhausting the iterator")
+	}
+}
+
+func TestReadArrayIterStreamsWithoutMaterializing(t *testing.T) {
+	src := RedisMessage{typ: '*', values: []RedisMessage{
+		{typ: '$', string: "a"},
+		{typ: ':', integer: 2},
+		{typ: '$', string: "c"},
+	}}
+	buf := &bytes.Buffer{}
+	if err := src.WriteRESP(buf); err != nil {
+		t.Fatalf("WriteRESP: %v", err)
+	}
+	it, err := ReadArrayIter(bufio.NewReader(buf))
+	if err != nil {
+		t.Fatalf("ReadArrayIter: %v", err)
+	}
+	var got []RedisMessage
+	for it.Next() {
+		v, err := it.Value()
+		if err != nil {
+			t.Fatalf("Value: %v", err)
+		}
+		got = append(got, v)
+	}
+	if len(got) != len(src.values) {
+		t.Fatalf("got %d elements, want %d", len(got), len(src.values))
+	}
+	for i := range got {
+		assertMessagesEqual(t, got[i], src.values[i])
+	}
+}
+
+func TestIterArrayRESP(t *testing.T) {
+	src := RedisMessage{typ: '~', values: []RedisMessage{{typ: ':', integer: 1}, {typ: ':', integer: 2}}}
+	buf := &bytes.Buffer{}
+	src.WriteRESP(buf)
+	var got []int64
+	err := IterArrayRESP(bufio.NewReader(buf), func(m RedisMessage) error {
+		got = append(got, m.integer)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("IterArrayRESP: %v", err)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("unexpected got: %v", got)
+	}
+}
+
+func TestReadMapIterRESP3(t *testing.T) {
+	src := RedisMessage{typ: '%', values: []RedisMessage{
+		{typ: '$', string: "k1"}, {typ: ':', integer: 1},
+		{typ: '$', string: "k2"}, {typ: ':', integer: 2},
+	}}
+	buf := &bytes.Buffer{}
+	src.WriteRESP(buf)
+	it, err := ReadMapIter(bufio.NewReader(buf))
+	if err != nil {
+		t.Fatalf("ReadMapIter: %v", err)
+	}
+	count := 0
+	for it.Next() {
+		k, v, err := it.Value()
+		if err != nil {
+			t.Fatalf("Value: %v", err)
+		}
+		if k.string != src.values[count*2].string || v.integer != src.values[count*2+1].integer {
+			t.Fatalf("unexpected pair %d: %+v %+v", count, k, v)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Fatalf("got %d pairs, want 2", count)
+	}
+}
+
+func TestReadMapIterRESP2AlternatingArray(t *testing.T) {
+	src := RedisMessage{typ: '*', values: []RedisMessage{
+		{typ: '$', string: "k1"}, {typ: '$', string: "v1"},
+	}}
+	buf := &bytes.Buffer{}
+	src.WriteRESP(buf)
+	var pairs int
+	err := IterMapRESP(bufio.NewReader(buf), func(k, v RedisMessage) error {
+		pairs++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("IterMapRESP: %v", err)
+	}
+	if pairs != 1 {
+		t.Fatalf("got %d pairs, want 1", pairs)
+	}
+}
+
+func TestReadArrayIterRejectsNonArray(t *testing.T) {
+	buf := bytes.NewBufferString("+OK\r\n")
+	if _, err := ReadArrayIter(bufio.NewReader(buf)); err == nil {
+		t.Fatalf("expected error reading a non-array reply as an array")
+	}
+}
@@ -0,0 +1,312 @@
+package rueidis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonMessage mirrors RedisMessage's unexported fields for JSON (and
+// MessagePack) round-tripping. The type byte is encoded as a single
+// character string so the wire format stays human readable.
+type jsonMessage struct {
+	Type    string        `json:"t"`
+	String  string        `json:"s,omitempty"`
+	Integer int64         `json:"i,omitempty"`
+	Values  []jsonMessage `json:"v,omitempty"`
+	Attrs   *jsonMessage  `json:"a,omitempty"`
+}
+
+func (m *RedisMessage) toJSON() jsonMessage {
+	j := jsonMessage{Type: string(m.typ), String: m.string, Integer: m.integer}
+	if len(m.values) != 0 {
+		j.Values = make([]jsonMessage, len(m.values))
+		for i := range m.values {
+			j.Values[i] = m.values[i].toJSON()
+		}
+	}
+	if m.attrs != nil && m.attrs != cacheMark {
+		a := m.attrs.toJSON()
+		j.Attrs = &a
+	}
+	return j
+}
+
+func (j *jsonMessage) toMessage() (RedisMessage, error) {
+	if len(j.Type) != 1 {
+		return RedisMessage{}, fmt.Errorf("rueidis: invalid RedisMessage type %q", j.Type)
+	}
+	m := RedisMessage{typ: j.Type[0], string: j.String, integer: j.Integer}
+	if len(j.Values) != 0 {
+		m.values = make([]RedisMessage, len(j.Values))
+		for i := range j.Values {
+			v, err := j.Values[i].toMessage()
+			if err != nil {
+				return RedisMessage{}, err
+			}
+			m.values[i] = v
+		}
+	}
+	if j.Attrs != nil {
+		attrs, err := j.Attrs.toMessage()
+		if err != nil {
+			return RedisMessage{}, err
+		}
+		m.attrs = &attrs
+	}
+	return m, nil
+}
+
+// MarshalJSON encodes the message with full RESP3 type fidelity (type byte,
+// string, integer, nested values and attributes), so a cached reply can be
+// persisted to disk or logged and later rehydrated with UnmarshalJSON.
+func (m *RedisMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.toJSON())
+}
+
+// UnmarshalJSON decodes a message previously produced by MarshalJSON.
+func (m *RedisMessage) UnmarshalJSON(b []byte) error {
+	var j jsonMessage
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	v, err := j.toMessage()
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// WriteRESP writes the canonical RESP3 wire form of the message to w,
+// attributes included, so tests can round-trip a RedisMessage through
+// ReadRESP without a live server.
+func (m *RedisMessage) WriteRESP(w io.Writer) error {
+	bw, ok := w.(*bytes.Buffer)
+	if !ok {
+		bw = &bytes.Buffer{}
+		defer func() {
+			if bw.Len() != 0 {
+				w.Write(bw.Bytes())
+			}
+		}()
+	}
+	return m.writeRESP(bw)
+}
+
+func (m *RedisMessage) writeRESP(w *bytes.Buffer) error {
+	if m.attrs != nil && m.attrs != cacheMark {
+		w.WriteByte('|')
+		w.WriteString(strconv.Itoa(len(m.attrs.values) / 2))
+		w.WriteString("\r\n")
+		for i := range m.attrs.values {
+			if err := m.attrs.values[i].writeRESP(w); err != nil {
+				return err
+			}
+		}
+	}
+	switch m.typ {
+	case '_':
+		w.WriteString("_\r\n")
+		return nil
+	case '#':
+		w.WriteByte('#')
+		if m.integer == 1 {
+			w.WriteByte('t')
+		} else {
+			w.WriteByte('f')
+		}
+		w.WriteString("\r\n")
+		return nil
+	case ':':
+		w.WriteByte(m.typ)
+		w.WriteString(strconv.FormatInt(m.integer, 10))
+		w.WriteString("\r\n")
+		return nil
+	case ',', '(':
+		w.WriteByte(m.typ)
+		w.WriteString(m.string)
+		w.WriteString("\r\n")
+		return nil
+	case '+', '-':
+		w.WriteByte(m.typ)
+		w.WriteString(m.string)
+		w.WriteString("\r\n")
+		return nil
+	case '$', '=', '!':
+		w.WriteByte(m.typ)
+		w.WriteString(strconv.Itoa(len(m.string)))
+		w.WriteString("\r\n")
+		w.WriteString(m.string)
+		w.WriteString("\r\n")
+		return nil
+	case '*', '~', '>':
+		w.WriteByte(m.typ)
+		w.WriteString(strconv.Itoa(len(m.values)))
+		w.WriteString("\r\n")
+		for i := range m.values {
+			if err := m.values[i].writeRESP(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	case '%':
+		w.WriteByte('%')
+		w.WriteString(strconv.Itoa(len(m.values) / 2))
+		w.WriteString("\r\n")
+		for i := range m.values {
+			if err := m.values[i].writeRESP(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("rueidis: WriteRESP unsupported message type %q", m.typ)
+	}
+}
+
+// ReadRESP reads one message in the wire form written by WriteRESP,
+// including a leading `|` attribute reply, so captured RESP traffic (or a
+// WriteRESP round trip) can be fed back into tests without a live server.
+//
+// The whole reply tree is built from one messageArena (unless
+// DisableMessagePool is set), so the top-level RedisMessage's Release
+// (via RedisResult.Release) returns it to the pool in one go instead of
+// leaving every nested array/map node to the garbage collector.
+func ReadRESP(r *bufio.Reader) (RedisMessage, error) {
+	arena := newMessageArena()
+	m, err := readRESPWithArena(r, arena)
+	if err != nil {
+		if arena != nil {
+			arena.buf = arena.buf[:0]
+			arenaPool.Put(arena)
+		}
+		return RedisMessage{}, err
+	}
+	m.pool = arena
+	return m, nil
+}
+
+func readRESPWithArena(r *bufio.Reader, arena *messageArena) (RedisMessage, error) {
+	var attrs *RedisMessage
+	line, err := readRESPLine(r)
+	if err != nil {
+		return RedisMessage{}, err
+	}
+	if line[0] == '|' {
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return RedisMessage{}, fmt.Errorf("rueidis: invalid RESP attribute count %q", line[1:])
+		}
+		a := RedisMessage{typ: '%', values: arenaAlloc(arena, n*2)}
+		for i := range a.values {
+			if a.values[i], err = readRESPWithArena(r, arena); err != nil {
+				return RedisMessage{}, err
+			}
+		}
+		attrs = &a
+		if line, err = readRESPLine(r); err != nil {
+			return RedisMessage{}, err
+		}
+	}
+	m, err := readRESPMessage(r, line, arena)
+	if err != nil {
+		return RedisMessage{}, err
+	}
+	m.attrs = attrs
+	return m, nil
+}
+
+// arenaAlloc carves n elements out of arena, or falls back to a plain
+// allocation when arena is nil (DisableMessagePool).
+func arenaAlloc(arena *messageArena, n int) []RedisMessage {
+	if arena == nil {
+		return make([]RedisMessage, n)
+	}
+	return arena.alloc(n)
+}
+
+func readRESPLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("rueidis: malformed RESP line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// readRESPHeader reads one RESP line and splits it into its type byte and
+// body, for callers (such as ReadArrayIter/ReadMapIter) that decode an
+// array/map header themselves instead of a full message via ReadRESP.
+func readRESPHeader(r *bufio.Reader) (typ byte, body string, err error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return 0, "", err
+	}
+	return line[0], line[1:], nil
+}
+
+func readRESPMessage(r *bufio.Reader, line string, arena *messageArena) (RedisMessage, error) {
+	typ, body := line[0], line[1:]
+	switch typ {
+	case '_':
+		return RedisMessage{typ: typ}, nil
+	case '#':
+		return RedisMessage{typ: typ, integer: boolInt(body == "t")}, nil
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return RedisMessage{}, err
+		}
+		return RedisMessage{typ: typ, integer: n}, nil
+	case ',', '(', '+', '-':
+		return RedisMessage{typ: typ, string: body}, nil
+	case '$', '=', '!':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return RedisMessage{}, err
+		}
+		if n < 0 {
+			// RESP2 null bulk string ($-1\r\n): header only, no body.
+			return RedisMessage{typ: '_'}, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return RedisMessage{}, err
+		}
+		return RedisMessage{typ: typ, string: string(buf[:n])}, nil
+	case '*', '~', '>', '%':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return RedisMessage{}, err
+		}
+		if n < 0 {
+			// RESP2 null array (*-1\r\n): header only, no elements.
+			return RedisMessage{typ: '_'}, nil
+		}
+		if typ == '%' {
+			n *= 2
+		}
+		values := arenaAlloc(arena, n)
+		for i := range values {
+			if values[i], err = readRESPWithArena(r, arena); err != nil {
+				return RedisMessage{}, err
+			}
+		}
+		return RedisMessage{typ: typ, values: values}, nil
+	default:
+		return RedisMessage{}, fmt.Errorf("rueidis: ReadRESP unsupported message type %q", typ)
+	}
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package rueidis
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func assertMessagesEqual(t *testing.T, got, want RedisMessage) {
+	t.Helper()
+	if got.typ != want.typ || got.string != want.string || got.integer != want.integer {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if len(got.values) != len(want.values) {
+		t.Fatalf("got %d values, want %d", len(got.values), len(want.values))
+	}
+	for i := range got.values {
+		assertMessagesEqual(t, got.values[i], want.values[i])
+	}
+	if (got.attrs == nil) != (want.attrs == nil) {
+		t.Fatalf("attrs mismatch: got %v, want %v", got.attrs, want.attrs)
+	}
+	if got.attrs != nil {
+		assertMessagesEqual(t, *got.attrs, *want.attrs)
+	}
+}
+
+func TestWriteReadRESPRoundTrip(t *testing.T) {
+	cases := []RedisMessage{
+		{typ: '_'},
+		{typ: '#', integer: 1},
+		{typ: '#', integer: 0},
+		{typ: ':', integer: 42},
+		{typ: '(', string: "123456789012345678901234567890"},
+		{typ: ',', string: "3.14"},
+		{typ: '+', string: "OK"},
+		{typ: '-', string: "ERR oops"},
+		{typ: '$', string: "hello world"},
+		{typ: '*', values: []RedisMessage{{typ: '$', string: "a"}, {typ: ':', integer: 1}}},
+		{typ: '%', values: []RedisMessage{{typ: '$', string: "k"}, {typ: '$', string: "v"}}},
+		{
+			typ:    '+',
+			string: "OK",
+			attrs:  &RedisMessage{typ: '%', values: []RedisMessage{{typ: '$', string: "ttl"}, {typ: ':', integer: 10}}},
+		},
+	}
+	for _, m := range cases {
+		m := m
+		buf := &bytes.Buffer{}
+		if err := m.WriteRESP(buf); err != nil {
+			t.Fatalf("WriteRESP(%+v): %v", m, err)
+		}
+		got, err := ReadRESP(bufio.NewReader(buf))
+		if err != nil {
+			t.Fatalf("ReadRESP after WriteRESP(%+v): %v", m, err)
+		}
+		assertMessagesEqual(t, got, m)
+	}
+}
+
+func TestReadRESPNullBulkString(t *testing.T) {
+	r := bufio.NewReader(bytes.NewBufferString("$-1\r\n"))
+	got, err := ReadRESP(r)
+	if err != nil {
+		t.Fatalf("ReadRESP($-1): %v", err)
+	}
+	if got.typ != '_' {
+		t.Fatalf("got typ %q, want nil message", got.typ)
+	}
+}
+
+func TestReadRESPNullArray(t *testing.T) {
+	r := bufio.NewReader(bytes.NewBufferString("*-1\r\n"))
+	got, err := ReadRESP(r)
+	if err != nil {
+		t.Fatalf("ReadRESP(*-1): %v", err)
+	}
+	if got.typ != '_' {
+		t.Fatalf("got typ %q, want nil message", got.typ)
+	}
+}
+
+func TestReadRESPNullBulkStringInArray(t *testing.T) {
+	// A GET-miss inside an MGET-style array: the stream must not desync
+	// on the null element, and the following element must still parse.
+	r := bufio.NewReader(bytes.NewBufferString("*2\r\n$-1\r\n$2\r\nok\r\n"))
+	got, err := ReadRESP(r)
+	if err != nil {
+		t.Fatalf("ReadRESP: %v", err)
+	}
+	if len(got.values) != 2 {
+		t.Fatalf("got %d values, want 2", len(got.values))
+	}
+	if got.values[0].typ != '_' {
+		t.Fatalf("values[0].typ = %q, want nil", got.values[0].typ)
+	}
+	if got.values[1].typ != '$' || got.values[1].string != "ok" {
+		t.Fatalf("values[1] = %+v, want {$, ok}", got.values[1])
+	}
+}
+
+func TestMarshalJSONRoundTrip(t *testing.T) {
+	m := RedisMessage{
+		typ:    '*',
+		values: []RedisMessage{{typ: '$', string: "a"}, {typ: ':', integer: 7}},
+		attrs:  &RedisMessage{typ: '%', values: []RedisMessage{{typ: '$', string: "k"}, {typ: '$', string: "v"}}},
+	}
+	b, err := m.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	var got RedisMessage
+	if err := got.UnmarshalJSON(b); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	assertMessagesEqual(t, got, m)
+}
@@ -2,6 +2,7 @@ package rueidis
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"unsafe"
@@ -35,18 +36,18 @@ func (r *RedisError) IsNil() bool {
 
 // IsMoved checks if it is a redis MOVED message and returns moved address.
 func (r *RedisError) IsMoved() (addr string, ok bool) {
-	if ok = strings.HasPrefix(r.string, "MOVED"); ok {
-		addr = strings.Split(r.string, " ")[2]
+	if info, ok := r.Redirect(); ok && info.Kind == RedirectMoved {
+		return info.Addr, true
 	}
-	return
+	return "", false
 }
 
 // IsAsk checks if it is a redis ASK message and returns ask address.
 func (r *RedisError) IsAsk() (addr string, ok bool) {
-	if ok = strings.HasPrefix(r.string, "ASK"); ok {
-		addr = strings.Split(r.string, " ")[2]
+	if info, ok := r.Redirect(); ok && info.Kind == RedirectAsk {
+		return info.Addr, true
 	}
-	return
+	return "", false
 }
 
 // IsTryAgain checks if it is a redis TRYAGAIN message and returns ask address.
@@ -59,6 +60,70 @@ func (r *RedisError) IsNoScript() bool {
 	return strings.HasPrefix(r.string, "NOSCRIPT")
 }
 
+// IsFunctionNotFound checks if it is a redis "Function not found" message,
+// the FCALL/FCALL_RO equivalent of NOSCRIPT for a library that has not (or
+// no longer) been FUNCTION LOADed onto the server.
+func (r *RedisError) IsFunctionNotFound() bool {
+	return strings.Contains(r.string, "Function not found")
+}
+
+// RedirectKind identifies the cluster redirection carried by a RedisError.
+type RedirectKind byte
+
+const (
+	// RedirectMoved means the error is a `MOVED <slot> <addr>` response.
+	RedirectMoved RedirectKind = iota + 1
+	// RedirectAsk means the error is an `ASK <slot> <addr>` response.
+	RedirectAsk
+	// RedirectTryAgain means the error is a `TRYAGAIN` response.
+	RedirectTryAgain
+)
+
+// RedirectInfo is the parsed form of a MOVED/ASK/TRYAGAIN redis error, as
+// returned by RedisError.Redirect.
+type RedirectInfo struct {
+	Addr string
+	Kind RedirectKind
+	Slot int64
+}
+
+// Redirect parses the message as a MOVED/ASK/TRYAGAIN response and returns
+// the slot and address it carries. The result is parsed once and cached on
+// the message, so repeated calls are cheap. It replaces manually splitting
+// RedisError.Error() on spaces, which panics on a malformed message and
+// throws away the slot number that cluster routing needs.
+func (r *RedisError) Redirect() (*RedirectInfo, bool) {
+	if r.redirect != nil {
+		return r.redirect, r.redirect.Kind != 0
+	}
+	info := &RedirectInfo{}
+	switch {
+	case strings.HasPrefix(r.string, "MOVED"):
+		info.Kind = RedirectMoved
+	case strings.HasPrefix(r.string, "ASK"):
+		info.Kind = RedirectAsk
+	case strings.HasPrefix(r.string, "TRYAGAIN"):
+		info.Kind = RedirectTryAgain
+	}
+	if info.Kind == 0 {
+		r.redirect = info
+		return info, false
+	}
+	fields := strings.Fields(r.string)
+	if len(fields) >= 2 {
+		info.Slot, _ = strconv.ParseInt(fields[1], 10, 64)
+	}
+	if len(fields) >= 3 {
+		if host, port, err := net.SplitHostPort(fields[2]); err == nil {
+			info.Addr = net.JoinHostPort(host, port)
+		} else {
+			info.Addr = fields[2]
+		}
+	}
+	r.redirect = info
+	return info, true
+}
+
 func newResult(val RedisMessage, err error) RedisResult {
 	return RedisResult{val: val, err: err}
 }
@@ -82,6 +147,44 @@ func (r RedisResult) RedisError() *RedisError {
 	return nil
 }
 
+// Redirect delegates to RedisError.Redirect. It returns ok == false if the
+// result is not a redis error, or the error is not a MOVED/ASK/TRYAGAIN response.
+//
+// Unlike RedisError, this takes a pointer receiver and aliases (*RedisError)(&r.val)
+// directly instead of going through RedisError(), so the *RedirectInfo it
+// caches sticks on r.val itself: a value-receiver RedisError() would hand
+// back a pointer into a fresh per-call copy, and the cache would never
+// survive past the call that produced it.
+func (r *RedisResult) Redirect() (*RedirectInfo, bool) {
+	if err := r.val.Error(); err != nil {
+		return (*RedisError)(&r.val).Redirect()
+	}
+	return nil, false
+}
+
+// NoScriptRetry returns fn() if the result is a redis NOSCRIPT error,
+// otherwise it returns r unchanged. It lets Script.Exec (and similar
+// EVALSHA-then-EVAL callers) express the NOSCRIPT fallback without
+// hand-rolling the RedisError.IsNoScript check at every call site.
+func (r RedisResult) NoScriptRetry(fn func() RedisResult) RedisResult {
+	if e := r.RedisError(); e != nil && e.IsNoScript() {
+		return fn()
+	}
+	return r
+}
+
+// FunctionNotFoundRetry returns fn() if the result is a redis "Function not
+// found" error, otherwise it returns r unchanged. It is the FCALL/FCALL_RO
+// counterpart of NoScriptRetry, letting FunctionScript.Exec express its
+// FUNCTION LOAD fallback without hand-rolling the RedisError.IsFunctionNotFound
+// check itself.
+func (r RedisResult) FunctionNotFoundRetry(fn func() RedisResult) RedisResult {
+	if e := r.RedisError(); e != nil && e.IsFunctionNotFound() {
+		return fn()
+	}
+	return r
+}
+
 // NonRedisError can be used to check if there is an underlying error (ex. network timeout).
 func (r RedisResult) NonRedisError() error {
 	return r.err
@@ -196,13 +299,39 @@ func (r RedisResult) IsCacheHit() bool {
 	return r.val.IsCacheHit()
 }
 
+// Scan delegates to RedisMessage.Scan
+func (r RedisResult) Scan(dest interface{}) error {
+	if err := r.Error(); err != nil {
+		return err
+	}
+	return r.val.Scan(dest)
+}
+
+// ScanSlice delegates to RedisMessage.ScanSlice
+func (r RedisResult) ScanSlice(dest interface{}) error {
+	if err := r.Error(); err != nil {
+		return err
+	}
+	return r.val.ScanSlice(dest)
+}
+
+// ScanMap delegates to RedisMessage.ScanMap
+func (r RedisResult) ScanMap(dest interface{}) error {
+	if err := r.Error(); err != nil {
+		return err
+	}
+	return r.val.ScanMap(dest)
+}
+
 // RedisMessage is a redis response message, it may be a nil response
 type RedisMessage struct {
-	typ     byte
-	string  string
-	integer int64
-	values  []RedisMessage
-	attrs   *RedisMessage
+	typ      byte
+	string   string
+	integer  int64
+	values   []RedisMessage
+	attrs    *RedisMessage
+	redirect *RedirectInfo
+	pool     *messageArena
 }
 
 // IsNil check if message is a redis nil response
@@ -0,0 +1,104 @@
+package rueidis
+
+import "testing"
+
+func newErrorMessage(s string) RedisMessage {
+	return RedisMessage{typ: '-', string: s}
+}
+
+func TestRedisErrorRedirectMoved(t *testing.T) {
+	m := newErrorMessage("MOVED 1234 127.0.0.1:6379")
+	e := (*RedisError)(&m)
+	info, ok := e.Redirect()
+	if !ok || info.Kind != RedirectMoved || info.Slot != 1234 || info.Addr != "127.0.0.1:6379" {
+		t.Fatalf("unexpected redirect info: %+v ok=%v", info, ok)
+	}
+	addr, ok := e.IsMoved()
+	if !ok || addr != "127.0.0.1:6379" {
+		t.Fatalf("IsMoved() = %q, %v", addr, ok)
+	}
+}
+
+func TestRedisErrorRedirectAskIPv6(t *testing.T) {
+	m := newErrorMessage("ASK 5678 [::1]:6379")
+	e := (*RedisError)(&m)
+	info, ok := e.Redirect()
+	if !ok || info.Kind != RedirectAsk || info.Slot != 5678 || info.Addr != "[::1]:6379" {
+		t.Fatalf("unexpected redirect info: %+v ok=%v", info, ok)
+	}
+	addr, ok := e.IsAsk()
+	if !ok || addr != "[::1]:6379" {
+		t.Fatalf("IsAsk() = %q, %v", addr, ok)
+	}
+}
+
+func TestRedisErrorRedirectTryAgain(t *testing.T) {
+	m := newErrorMessage("TRYAGAIN")
+	e := (*RedisError)(&m)
+	info, ok := e.Redirect()
+	if !ok || info.Kind != RedirectTryAgain {
+		t.Fatalf("unexpected redirect info: %+v ok=%v", info, ok)
+	}
+	if !e.IsTryAgain() {
+		t.Fatalf("IsTryAgain() = false")
+	}
+}
+
+func TestRedisErrorRedirectMalformed(t *testing.T) {
+	for _, s := range []string{"MOVED", "MOVED 1234", "ASK notaslot nothost", "ERR something else"} {
+		m := newErrorMessage(s)
+		e := (*RedisError)(&m)
+		info, ok := e.Redirect()
+		if s == "ERR something else" {
+			if ok {
+				t.Fatalf("%q: expected ok=false", s)
+			}
+			continue
+		}
+		if !ok {
+			t.Fatalf("%q: expected ok=true even when slot/addr are missing/invalid", s)
+		}
+		_ = info
+	}
+}
+
+func TestRedisErrorRedirectCached(t *testing.T) {
+	m := newErrorMessage("MOVED 1 127.0.0.1:6379")
+	e := (*RedisError)(&m)
+	first, _ := e.Redirect()
+	second, _ := e.Redirect()
+	if first != second {
+		t.Fatalf("Redirect() did not return the cached *RedirectInfo across calls")
+	}
+}
+
+func TestRedisResultRedirectCached(t *testing.T) {
+	res := newResult(newErrorMessage("MOVED 1 127.0.0.1:6379"), nil)
+	first, ok := res.Redirect()
+	if !ok {
+		t.Fatalf("expected ok=true")
+	}
+	second, _ := res.Redirect()
+	if first != second {
+		t.Fatalf("RedisResult.Redirect() did not cache onto the underlying message across calls")
+	}
+}
+
+func TestRedisResultRedirectNotAnError(t *testing.T) {
+	res := newResult(RedisMessage{typ: '+', string: "OK"}, nil)
+	if _, ok := res.Redirect(); ok {
+		t.Fatalf("expected ok=false for a non-error result")
+	}
+}
+
+// RedisError must keep a value receiver so chained calls on a
+// non-addressable RedisResult (the result of a function call) still
+// compile, the way callers write client.Do(ctx, cmd).RedisError().
+func TestRedisResultRedisErrorChainsOnNonAddressableValue(t *testing.T) {
+	makeResult := func() RedisResult {
+		return newResult(newErrorMessage("MOVED 1 127.0.0.1:6379"), nil)
+	}
+	if e := makeResult().RedisError(); e == nil {
+		t.Fatalf("expected a non-nil RedisError")
+	}
+}
@@ -0,0 +1,246 @@
+package rueidis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// MarshalMsgPack encodes the message as MessagePack using the same {t, s,
+// i, v, a} schema as MarshalJSON. It only implements the subset of
+// MessagePack needed for that schema, not a general-purpose encoder.
+func (m *RedisMessage) MarshalMsgPack() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	m.writeMsgPack(buf)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgPack decodes a message previously produced by MarshalMsgPack.
+func (m *RedisMessage) UnmarshalMsgPack(b []byte) error {
+	v, _, err := readMsgPackMessage(b)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+func (m *RedisMessage) writeMsgPack(buf *bytes.Buffer) {
+	fields := 3
+	hasValues := len(m.values) != 0
+	hasAttrs := m.attrs != nil && m.attrs != cacheMark
+	if hasValues {
+		fields++
+	}
+	if hasAttrs {
+		fields++
+	}
+	writeMsgPackMapHeader(buf, fields)
+
+	writeMsgPackStr(buf, "t")
+	writeMsgPackStr(buf, string(m.typ))
+
+	writeMsgPackStr(buf, "s")
+	writeMsgPackStr(buf, m.string)
+
+	writeMsgPackStr(buf, "i")
+	writeMsgPackInt(buf, m.integer)
+
+	if hasValues {
+		writeMsgPackStr(buf, "v")
+		writeMsgPackArrayHeader(buf, len(m.values))
+		for i := range m.values {
+			m.values[i].writeMsgPack(buf)
+		}
+	}
+	if hasAttrs {
+		writeMsgPackStr(buf, "a")
+		m.attrs.writeMsgPack(buf)
+	}
+}
+
+func writeMsgPackMapHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n <= 0x0f:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xde)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdf)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMsgPackArrayHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n <= 0x0f:
+		buf.WriteByte(0x90 | byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xdc)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdd)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMsgPackStr(buf *bytes.Buffer, s string) {
+	switch {
+	case len(s) <= 0x1f:
+		buf.WriteByte(0xa0 | byte(len(s)))
+	case len(s) <= 0xff:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(len(s)))
+	case len(s) <= 0xffff:
+		buf.WriteByte(0xda)
+		binary.Write(buf, binary.BigEndian, uint16(len(s)))
+	default:
+		buf.WriteByte(0xdb)
+		binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	}
+	buf.WriteString(s)
+}
+
+func writeMsgPackInt(buf *bytes.Buffer, v int64) {
+	buf.WriteByte(0xd3)
+	binary.Write(buf, binary.BigEndian, uint64(v))
+}
+
+func readMsgPackMessage(b []byte) (RedisMessage, []byte, error) {
+	n, b, err := readMsgPackMapHeader(b)
+	if err != nil {
+		return RedisMessage{}, nil, err
+	}
+	var m RedisMessage
+	for i := 0; i < n; i++ {
+		var key string
+		key, b, err = readMsgPackStr(b)
+		if err != nil {
+			return RedisMessage{}, nil, err
+		}
+		switch key {
+		case "t":
+			var t string
+			if t, b, err = readMsgPackStr(b); err != nil {
+				return RedisMessage{}, nil, err
+			}
+			if len(t) != 1 {
+				return RedisMessage{}, nil, fmt.Errorf("rueidis: invalid RedisMessage type %q", t)
+			}
+			m.typ = t[0]
+		case "s":
+			if m.string, b, err = readMsgPackStr(b); err != nil {
+				return RedisMessage{}, nil, err
+			}
+		case "i":
+			if m.integer, b, err = readMsgPackInt(b); err != nil {
+				return RedisMessage{}, nil, err
+			}
+		case "v":
+			var cnt int
+			if cnt, b, err = readMsgPackArrayHeader(b); err != nil {
+				return RedisMessage{}, nil, err
+			}
+			m.values = make([]RedisMessage, cnt)
+			for j := 0; j < cnt; j++ {
+				if m.values[j], b, err = readMsgPackMessage(b); err != nil {
+					return RedisMessage{}, nil, err
+				}
+			}
+		case "a":
+			var attrs RedisMessage
+			if attrs, b, err = readMsgPackMessage(b); err != nil {
+				return RedisMessage{}, nil, err
+			}
+			m.attrs = &attrs
+		default:
+			return RedisMessage{}, nil, fmt.Errorf("rueidis: unknown RedisMessage field %q", key)
+		}
+	}
+	return m, b, nil
+}
+
+func readMsgPackMapHeader(b []byte) (int, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, fmt.Errorf("rueidis: unexpected end of MessagePack input")
+	}
+	switch {
+	case b[0]&0xf0 == 0x80:
+		return int(b[0] & 0x0f), b[1:], nil
+	case b[0] == 0xde:
+		if len(b) < 3 {
+			return 0, nil, fmt.Errorf("rueidis: truncated MessagePack map16")
+		}
+		return int(binary.BigEndian.Uint16(b[1:3])), b[3:], nil
+	case b[0] == 0xdf:
+		if len(b) < 5 {
+			return 0, nil, fmt.Errorf("rueidis: truncated MessagePack map32")
+		}
+		return int(binary.BigEndian.Uint32(b[1:5])), b[5:], nil
+	default:
+		return 0, nil, fmt.Errorf("rueidis: expected MessagePack map, got byte 0x%x", b[0])
+	}
+}
+
+func readMsgPackArrayHeader(b []byte) (int, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, fmt.Errorf("rueidis: unexpected end of MessagePack input")
+	}
+	switch {
+	case b[0]&0xf0 == 0x90:
+		return int(b[0] & 0x0f), b[1:], nil
+	case b[0] == 0xdc:
+		if len(b) < 3 {
+			return 0, nil, fmt.Errorf("rueidis: truncated MessagePack array16")
+		}
+		return int(binary.BigEndian.Uint16(b[1:3])), b[3:], nil
+	case b[0] == 0xdd:
+		if len(b) < 5 {
+			return 0, nil, fmt.Errorf("rueidis: truncated MessagePack array32")
+		}
+		return int(binary.BigEndian.Uint32(b[1:5])), b[5:], nil
+	default:
+		return 0, nil, fmt.Errorf("rueidis: expected MessagePack array, got byte 0x%x", b[0])
+	}
+}
+
+func readMsgPackStr(b []byte) (string, []byte, error) {
+	if len(b) == 0 {
+		return "", nil, fmt.Errorf("rueidis: unexpected end of MessagePack input")
+	}
+	var n int
+	var rest []byte
+	switch {
+	case b[0]&0xe0 == 0xa0:
+		n, rest = int(b[0]&0x1f), b[1:]
+	case b[0] == 0xd9:
+		if len(b) < 2 {
+			return "", nil, fmt.Errorf("rueidis: truncated MessagePack str8")
+		}
+		n, rest = int(b[1]), b[2:]
+	case b[0] == 0xda:
+		if len(b) < 3 {
+			return "", nil, fmt.Errorf("rueidis: truncated MessagePack str16")
+		}
+		n, rest = int(binary.BigEndian.Uint16(b[1:3])), b[3:]
+	case b[0] == 0xdb:
+		if len(b) < 5 {
+			return "", nil, fmt.Errorf("rueidis: truncated MessagePack str32")
+		}
+		n, rest = int(binary.BigEndian.Uint32(b[1:5])), b[5:]
+	default:
+		return "", nil, fmt.Errorf("rueidis: expected MessagePack str, got byte 0x%x", b[0])
+	}
+	if len(rest) < n {
+		return "", nil, fmt.Errorf("rueidis: truncated MessagePack str body")
+	}
+	return string(rest[:n]), rest[n:], nil
+}
+
+func readMsgPackInt(b []byte) (int64, []byte, error) {
+	if len(b) < 9 || b[0] != 0xd3 {
+		return 0, nil, fmt.Errorf("rueidis: expected MessagePack int64")
+	}
+	return int64(binary.BigEndian.Uint64(b[1:9])), b[9:], nil
+}
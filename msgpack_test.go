@@ -0,0 +1,83 @@
+package rueidis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMarshalMsgPackRoundTrip(t *testing.T) {
+	m := RedisMessage{
+		typ:    '*',
+		values: []RedisMessage{{typ: '$', string: "a"}, {typ: ':', integer: 7}},
+		attrs:  &RedisMessage{typ: '%', values: []RedisMessage{{typ: '$', string: "k"}, {typ: '$', string: "v"}}},
+	}
+	b, err := m.MarshalMsgPack()
+	if err != nil {
+		t.Fatalf("MarshalMsgPack: %v", err)
+	}
+	var got RedisMessage
+	if err := got.UnmarshalMsgPack(b); err != nil {
+		t.Fatalf("UnmarshalMsgPack: %v", err)
+	}
+	assertMessagesEqual(t, got, m)
+}
+
+func TestMarshalMsgPackLargeString(t *testing.T) {
+	big := strings.Repeat("x", 1<<17) // 128KiB, past the str16 64KiB limit
+	buf := &bytes.Buffer{}
+	writeMsgPackStr(buf, big)
+	if buf.Bytes()[0] != 0xdb {
+		t.Fatalf("expected str32 marker 0xdb, got 0x%x", buf.Bytes()[0])
+	}
+	got, _, err := readMsgPackStr(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readMsgPackStr: %v", err)
+	}
+	if got != big {
+		t.Fatalf("round-tripped string corrupted, len got=%d want=%d", len(got), len(big))
+	}
+
+	m := RedisMessage{typ: '$', string: big}
+	b, err := m.MarshalMsgPack()
+	if err != nil {
+		t.Fatalf("MarshalMsgPack: %v", err)
+	}
+	var gotMsg RedisMessage
+	if err := gotMsg.UnmarshalMsgPack(b); err != nil {
+		t.Fatalf("UnmarshalMsgPack: %v", err)
+	}
+	if gotMsg.string != big {
+		t.Fatalf("round-tripped message corrupted, len got=%d want=%d", len(gotMsg.string), len(big))
+	}
+}
+
+func TestMsgPackArray32Header(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeMsgPackArrayHeader(buf, 1<<17)
+	n, rest, err := readMsgPackArrayHeader(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readMsgPackArrayHeader: %v", err)
+	}
+	if n != 1<<17 {
+		t.Fatalf("got n=%d, want %d", n, 1<<17)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("expected header to consume all bytes, %d left", len(rest))
+	}
+}
+
+func TestMsgPackMap32Header(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeMsgPackMapHeader(buf, 1<<17)
+	n, rest, err := readMsgPackMapHeader(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readMsgPackMapHeader: %v", err)
+	}
+	if n != 1<<17 {
+		t.Fatalf("got n=%d, want %d", n, 1<<17)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("expected header to consume all bytes, %d left", len(rest))
+	}
+}
@@ -0,0 +1,327 @@
+package rueidis
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RESPUnmarshaler is implemented by types that know how to decode themselves
+// from the raw bytes of a RESP scalar reply (a `$`, `+`, `:`, `,`, `=`, `(`,
+// or `#` message). Scan, ScanSlice and ScanMap call it instead of their built-in
+// numeric/string coercion whenever the destination type implements it.
+type RESPUnmarshaler interface {
+	UnmarshalRESP(b []byte) error
+}
+
+// Scan decodes a hash-like reply (a RESP3 `%` map, or the `*`/`~` alternating
+// key/value array returned by commands such as HGETALL) into dest, which
+// must be a non-nil pointer to a struct or a map.
+//
+// Struct fields are matched against reply keys using the `redis:"name"` tag,
+// falling back to the field name itself when no tag is present. A tag of
+// `redis:"-"` skips the field, and `redis:"name,omitempty"` leaves the
+// field unchanged instead of erroring when the reply carries a nil for
+// that key. Fields whose type implements RESPUnmarshaler are decoded
+// via UnmarshalRESP; a big.Int field accepts a RESP3 `(` big number too
+// large for int64; everything else is coerced between redis
+// string/integer/double replies and Go string/int/float/bool kinds.
+func (m *RedisMessage) Scan(dest interface{}) error {
+	values, err := m.toPairs()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("rueidis: Scan(non-pointer %T)", dest)
+	}
+	rv = rv.Elem()
+	switch rv.Kind() {
+	case reflect.Struct:
+		return scanStruct(values, rv)
+	case reflect.Map:
+		return scanMap(values, rv)
+	default:
+		return fmt.Errorf("rueidis: Scan(unsupported type %T)", dest)
+	}
+}
+
+// ScanSlice decodes an array reply, such as the result of MGET, into dest,
+// a pointer to a slice. Each element is coerced independently, so arrays of
+// scalars as well as arrays of sub-arrays/sub-structs are both supported.
+func (m *RedisMessage) ScanSlice(dest interface{}) error {
+	values, err := m.ToArray()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("rueidis: ScanSlice(dest must be a pointer to a slice, got %T)", dest)
+	}
+	sv := rv.Elem()
+	out := reflect.MakeSlice(sv.Type(), len(values), len(values))
+	for i, v := range values {
+		if err := decodeValue(v, out.Index(i)); err != nil {
+			return err
+		}
+	}
+	sv.Set(out)
+	return nil
+}
+
+// ScanMap decodes a hash-like reply into dest, a pointer to a map. It works
+// uniformly on a RESP3 `%` map reply and the RESP2 alternating key/value
+// array returned by commands such as HGETALL.
+func (m *RedisMessage) ScanMap(dest interface{}) error {
+	values, err := m.toPairs()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Map {
+		return fmt.Errorf("rueidis: ScanMap(dest must be a pointer to a map, got %T)", dest)
+	}
+	return scanMap(values, rv.Elem())
+}
+
+// toPairs normalizes a RESP3 map reply or a RESP2 alternating key/value
+// array into a flat []RedisMessage of key, value, key, value, ...
+func (m *RedisMessage) toPairs() ([]RedisMessage, error) {
+	if m.typ == '%' || m.typ == '*' || m.typ == '~' {
+		return m.values, nil
+	}
+	if err := m.Error(); err != nil {
+		return nil, err
+	}
+	panic(fmt.Sprintf("redis message type %c is not a map/array/set", m.typ))
+}
+
+func scanStruct(pairs []RedisMessage, rv reflect.Value) error {
+	fields := structFields(rv.Type())
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key, err := pairs[i].ToString()
+		if err != nil {
+			return err
+		}
+		f, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if f.omitempty && pairs[i+1].IsNil() {
+			continue
+		}
+		if err := decodeValue(pairs[i+1], rv.Field(f.idx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structField is the decode target for one redis reply key: the struct
+// field index, plus whether its tag carried `,omitempty`.
+type structField struct {
+	idx       int
+	omitempty bool
+}
+
+// structFields maps the redis tag name (or field name) to the field it
+// targets. A tag of `redis:"name,omitempty"` leaves the field unchanged
+// instead of erroring when the reply carries a nil for that key.
+func structFields(t reflect.Type) map[string]structField {
+	fields := make(map[string]structField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("redis"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = structField{idx: i, omitempty: opts == "omitempty"}
+	}
+	return fields
+}
+
+func scanMap(pairs []RedisMessage, rv reflect.Value) error {
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(rv.Type(), len(pairs)/2))
+	}
+	kt, vt := rv.Type().Key(), rv.Type().Elem()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		kv := reflect.New(kt).Elem()
+		if err := decodeValue(pairs[i], kv); err != nil {
+			return err
+		}
+		vv := reflect.New(vt).Elem()
+		if err := decodeValue(pairs[i+1], vv); err != nil {
+			return err
+		}
+		rv.SetMapIndex(kv, vv)
+	}
+	return nil
+}
+
+// respBytes returns the raw payload a RESPUnmarshaler should see for m: the
+// string itself for string-bearing replies ($/+/-/=/!/(), and the formatted
+// form of m.integer for the two replies that carry their value there
+// instead (`:` integer and `#` boolean), since m.string is empty for those.
+func respBytes(m RedisMessage) []byte {
+	switch m.typ {
+	case ':':
+		return []byte(strconv.FormatInt(m.integer, 10))
+	case '#':
+		if m.integer == 1 {
+			return []byte("t")
+		}
+		return []byte("f")
+	default:
+		return []byte(m.string)
+	}
+}
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+func decodeValue(m RedisMessage, rv reflect.Value) error {
+	if rv.CanAddr() {
+		if u, ok := rv.Addr().Interface().(RESPUnmarshaler); ok {
+			return u.UnmarshalRESP(respBytes(m))
+		}
+	}
+	if rv.Type() == bigIntType {
+		return scanBigInt(m, rv)
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		s, err := m.ToString()
+		if err != nil {
+			return err
+		}
+		rv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := scanInt64(m)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := scanFloat64(m)
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	case reflect.Bool:
+		b, err := scanBool(m)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Struct:
+		pairs, err := m.toPairs()
+		if err != nil {
+			return err
+		}
+		return scanStruct(pairs, rv)
+	case reflect.Map:
+		pairs, err := m.toPairs()
+		if err != nil {
+			return err
+		}
+		return scanMap(pairs, rv)
+	case reflect.Slice:
+		values, err := m.ToArray()
+		if err != nil {
+			return err
+		}
+		out := reflect.MakeSlice(rv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := decodeValue(v, out.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(out)
+	case reflect.Ptr:
+		rv.Set(reflect.New(rv.Type().Elem()))
+		return decodeValue(m, rv.Elem())
+	default:
+		return fmt.Errorf("rueidis: Scan(unsupported destination kind %s)", rv.Kind())
+	}
+	return nil
+}
+
+// scanBigInt decodes a RESP3 `(` big number (or a `:` integer/`$`/`+`
+// string reply) into a big.Int, for values that don't fit in int64.
+func scanBigInt(m RedisMessage, rv reflect.Value) error {
+	var s string
+	var err error
+	switch m.typ {
+	case '(':
+		s = m.string
+	case ':':
+		s = strconv.FormatInt(m.integer, 10)
+	default:
+		if s, err = m.ToString(); err != nil {
+			return err
+		}
+	}
+	bi := new(big.Int)
+	if _, ok := bi.SetString(s, 10); !ok {
+		return fmt.Errorf("rueidis: invalid big number %q", s)
+	}
+	rv.Set(reflect.ValueOf(*bi))
+	return nil
+}
+
+// scanInt64 coerces a `:` integer, `,` double, or `$`/`+` bulk/simple string
+// reply into an int64.
+func scanInt64(m RedisMessage) (int64, error) {
+	switch m.typ {
+	case ':':
+		return m.integer, nil
+	case ',':
+		f, err := strconv.ParseFloat(m.string, 64)
+		return int64(f), err
+	default:
+		s, err := m.ToString()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(s, 10, 64)
+	}
+}
+
+// scanFloat64 coerces a `,` double, `:` integer, or `$`/`+` bulk/simple
+// string reply into a float64.
+func scanFloat64(m RedisMessage) (float64, error) {
+	switch m.typ {
+	case ',':
+		return strconv.ParseFloat(m.string, 64)
+	case ':':
+		return float64(m.integer), nil
+	default:
+		s, err := m.ToString()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseFloat(s, 64)
+	}
+}
+
+// scanBool coerces a `#` boolean, `:` integer, or `$`/`+` bulk/simple string
+// reply into a bool.
+func scanBool(m RedisMessage) (bool, error) {
+	switch m.typ {
+	case '#':
+		return m.integer == 1, nil
+	case ':':
+		return m.integer == 1, nil
+	default:
+		s, err := m.ToString()
+		if err != nil {
+			return false, err
+		}
+		return strconv.ParseBool(s)
+	}
+}
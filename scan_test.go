@@ -0,0 +1,151 @@
+package rueidis
+
+import (
+	"math/big"
+	"testing"
+)
+
+func strMsg(s string) RedisMessage { return RedisMessage{typ: '$', string: s} }
+func intMsg(i int64) RedisMessage  { return RedisMessage{typ: ':', integer: i} }
+func nilMsg() RedisMessage         { return RedisMessage{typ: '_'} }
+
+func pairsMsg(pairs ...RedisMessage) RedisMessage {
+	return RedisMessage{typ: '%', values: pairs}
+}
+
+func arrMsg(values ...RedisMessage) RedisMessage {
+	return RedisMessage{typ: '*', values: values}
+}
+
+func TestScanStruct(t *testing.T) {
+	m := pairsMsg(strMsg("name"), strMsg("bob"), strMsg("age"), intMsg(30))
+	var dest struct {
+		Name string `redis:"name"`
+		Age  int    `redis:"age"`
+	}
+	if err := m.Scan(&dest); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if dest.Name != "bob" || dest.Age != 30 {
+		t.Fatalf("unexpected dest: %+v", dest)
+	}
+}
+
+func TestScanStructOmitempty(t *testing.T) {
+	m := pairsMsg(strMsg("name"), strMsg("bob"), strMsg("nick"), nilMsg())
+	var dest struct {
+		Name string `redis:"name"`
+		Nick string `redis:"nick,omitempty"`
+	}
+	dest.Nick = "unchanged"
+	if err := m.Scan(&dest); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if dest.Nick != "unchanged" {
+		t.Fatalf("omitempty field was overwritten: %q", dest.Nick)
+	}
+}
+
+func TestScanStructNilWithoutOmitemptyErrors(t *testing.T) {
+	m := pairsMsg(strMsg("name"), nilMsg())
+	var dest struct {
+		Name string `redis:"name"`
+	}
+	if err := m.Scan(&dest); err == nil {
+		t.Fatalf("expected error decoding nil into non-omitempty field")
+	}
+}
+
+func TestScanMap(t *testing.T) {
+	m := pairsMsg(strMsg("a"), intMsg(1), strMsg("b"), intMsg(2))
+	dest := map[string]int{}
+	if err := m.ScanMap(&dest); err != nil {
+		t.Fatalf("ScanMap: %v", err)
+	}
+	if dest["a"] != 1 || dest["b"] != 2 {
+		t.Fatalf("unexpected dest: %+v", dest)
+	}
+}
+
+func TestScanSlice(t *testing.T) {
+	m := arrMsg(strMsg("x"), strMsg("y"))
+	var dest []string
+	if err := m.ScanSlice(&dest); err != nil {
+		t.Fatalf("ScanSlice: %v", err)
+	}
+	if len(dest) != 2 || dest[0] != "x" || dest[1] != "y" {
+		t.Fatalf("unexpected dest: %+v", dest)
+	}
+}
+
+func TestScanRESP3Map(t *testing.T) {
+	m := RedisMessage{typ: '%', values: []RedisMessage{strMsg("k"), intMsg(7)}}
+	dest := map[string]int{}
+	if err := m.ScanMap(&dest); err != nil {
+		t.Fatalf("ScanMap: %v", err)
+	}
+	if dest["k"] != 7 {
+		t.Fatalf("unexpected dest: %+v", dest)
+	}
+}
+
+type customString string
+
+func (c *customString) UnmarshalRESP(b []byte) error {
+	*c = customString("custom:" + string(b))
+	return nil
+}
+
+func TestScanRESPUnmarshaler(t *testing.T) {
+	m := pairsMsg(strMsg("v"), strMsg("raw"))
+	var dest struct {
+		V customString `redis:"v"`
+	}
+	if err := m.Scan(&dest); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if dest.V != "custom:raw" {
+		t.Fatalf("unexpected dest.V: %q", dest.V)
+	}
+}
+
+func TestScanRESPUnmarshalerGetsIntegerAndBooleanPayload(t *testing.T) {
+	m := pairsMsg(strMsg("n"), intMsg(42), strMsg("b"), RedisMessage{typ: '#', integer: 1})
+	var dest struct {
+		N customString `redis:"n"`
+		B customString `redis:"b"`
+	}
+	if err := m.Scan(&dest); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if dest.N != "custom:42" {
+		t.Fatalf("unexpected dest.N: %q", dest.N)
+	}
+	if dest.B != "custom:t" {
+		t.Fatalf("unexpected dest.B: %q", dest.B)
+	}
+}
+
+func TestScanBigInt(t *testing.T) {
+	big96, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	m := pairsMsg(strMsg("n"), RedisMessage{typ: '(', string: big96.String()})
+	var dest struct {
+		N big.Int `redis:"n"`
+	}
+	if err := m.Scan(&dest); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if dest.N.Cmp(big96) != 0 {
+		t.Fatalf("unexpected dest.N: %s", dest.N.String())
+	}
+}
+
+func TestScanBigIntOverflowsInt64(t *testing.T) {
+	m := pairsMsg(strMsg("n"), RedisMessage{typ: '(', string: "123456789012345678901234567890"})
+	var dest struct {
+		N int64 `redis:"n"`
+	}
+	if err := m.Scan(&dest); err == nil {
+		t.Fatalf("expected error decoding an oversized big number into int64")
+	}
+}
@@ -0,0 +1,143 @@
+package rueidis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+)
+
+// Do is the shape of the single-command executor a Script or FunctionScript
+// needs to run itself. It is satisfied by Client.Do once bound to a command
+// builder upstream of this package; it is kept minimal here so Script does
+// not depend on the command-building layer.
+type Do func(ctx context.Context, args ...string) RedisResult
+
+// DoMulti is the pipeline-friendly counterpart of Do: it runs several
+// commands as one round trip and returns one RedisResult per command, in
+// order.
+type DoMulti func(ctx context.Context, cmds ...[]string) []RedisResult
+
+// Script represents a Lua script to be run with EVALSHA, falling back to
+// EVAL when the server reports NOSCRIPT. The script's SHA1 is computed once,
+// in NewScript.
+type Script struct {
+	body    string
+	sha     string
+	numkeys int
+}
+
+// NewScript builds a Script that operates on the first numkeys arguments
+// of Exec as KEYS and the rest as ARGV, mirroring the EVAL/EVALSHA calling
+// convention.
+func NewScript(numkeys int, body string) *Script {
+	sum := sha1.Sum([]byte(body))
+	return &Script{body: body, numkeys: numkeys, sha: hex.EncodeToString(sum[:])}
+}
+
+func (s *Script) evalshaArgs(keys, args []string) []string {
+	return s.buildArgs("EVALSHA", s.sha, keys, args)
+}
+
+func (s *Script) evalArgs(keys, args []string) []string {
+	return s.buildArgs("EVAL", s.body, keys, args)
+}
+
+func (s *Script) buildArgs(cmd, script string, keys, args []string) []string {
+	out := make([]string, 0, 3+len(keys)+len(args))
+	out = append(out, cmd, script, strconv.Itoa(s.numkeys))
+	out = append(out, keys...)
+	out = append(out, args...)
+	return out
+}
+
+// Exec runs the script with EVALSHA and transparently retries with EVAL on
+// a NOSCRIPT error, so callers never need to check RedisError.IsNoScript
+// themselves.
+func (s *Script) Exec(ctx context.Context, do Do, keys, args []string) RedisResult {
+	res := do(ctx, s.evalshaArgs(keys, args)...)
+	return res.NoScriptRetry(func() RedisResult {
+		return do(ctx, s.evalArgs(keys, args)...)
+	})
+}
+
+// ScriptInvocation is one Script.Exec call batched into ExecMulti.
+type ScriptInvocation struct {
+	Keys []string
+	Args []string
+}
+
+// ExecMulti runs many invocations of the same script in a single EVALSHA
+// pipeline and, if any of them fail with NOSCRIPT, reloads the script with
+// a single EVAL pipeline covering only the failed invocations, instead of
+// falling back to EVAL once per invocation.
+func (s *Script) ExecMulti(ctx context.Context, doMulti DoMulti, invocations ...ScriptInvocation) []RedisResult {
+	cmds := make([][]string, len(invocations))
+	for i, inv := range invocations {
+		cmds[i] = s.evalshaArgs(inv.Keys, inv.Args)
+	}
+	results := doMulti(ctx, cmds...)
+
+	var retry []int
+	for i, res := range results {
+		if e := res.RedisError(); e != nil && e.IsNoScript() {
+			retry = append(retry, i)
+		}
+	}
+	if len(retry) == 0 {
+		return results
+	}
+
+	evalCmds := make([][]string, len(retry))
+	for j, i := range retry {
+		evalCmds[j] = s.evalArgs(invocations[i].Keys, invocations[i].Args)
+	}
+	evalResults := doMulti(ctx, evalCmds...)
+	for j, i := range retry {
+		results[i] = evalResults[j]
+	}
+	return results
+}
+
+// FunctionScript represents a Redis Function (FCALL/FCALL_RO), with the
+// same missing-on-the-server fallback as Script except that the recovery
+// path is a FUNCTION LOAD of the owning library rather than an EVAL of
+// inline source.
+type FunctionScript struct {
+	name     string
+	readOnly bool
+	library  string
+}
+
+// NewFunctionScript builds a FunctionScript for the function named name,
+// belonging to the given library source (used to FUNCTION LOAD it back if
+// the server reports it missing). Use readOnly for functions registered
+// with the no-writes flag, so Exec issues FCALL_RO instead of FCALL.
+func NewFunctionScript(name, library string, readOnly bool) *FunctionScript {
+	return &FunctionScript{name: name, library: library, readOnly: readOnly}
+}
+
+func (f *FunctionScript) fcallArgs(keys, args []string) []string {
+	cmd := "FCALL"
+	if f.readOnly {
+		cmd = "FCALL_RO"
+	}
+	out := make([]string, 0, 3+len(keys)+len(args))
+	out = append(out, cmd, f.name, strconv.Itoa(len(keys)))
+	out = append(out, keys...)
+	out = append(out, args...)
+	return out
+}
+
+// Exec runs the function with FCALL/FCALL_RO and, on a NOSCRIPT-equivalent
+// "function not found" error, reloads the owning library with FUNCTION
+// LOAD REPLACE before retrying once.
+func (f *FunctionScript) Exec(ctx context.Context, do Do, keys, args []string) RedisResult {
+	res := do(ctx, f.fcallArgs(keys, args)...)
+	return res.FunctionNotFoundRetry(func() RedisResult {
+		if r := do(ctx, "FUNCTION", "LOAD", "REPLACE", f.library); r.Error() != nil {
+			return r
+		}
+		return do(ctx, f.fcallArgs(keys, args)...)
+	})
+}
@@ -0,0 +1,68 @@
+package rueidis
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFunctionScriptExecReloadsOnFunctionNotFound(t *testing.T) {
+	f := NewFunctionScript("myfunc", "#!lua name=mylib\nredis.register_function('myfunc', function() end)", false)
+	var calls []string
+	do := func(ctx context.Context, args ...string) RedisResult {
+		calls = append(calls, args[0])
+		switch args[0] {
+		case "FCALL":
+			if len(calls) == 1 {
+				return newResult(newErrorMessage("ERR Function not found"), nil)
+			}
+			return newResult(RedisMessage{typ: '+', string: "OK"}, nil)
+		case "FUNCTION":
+			return newResult(RedisMessage{typ: '+', string: "OK"}, nil)
+		default:
+			t.Fatalf("unexpected command %v", args)
+			return RedisResult{}
+		}
+	}
+	res := f.Exec(context.Background(), do, nil, nil)
+	if err := res.Error(); err != nil {
+		t.Fatalf("Exec returned error: %v", err)
+	}
+	if len(calls) != 3 || calls[0] != "FCALL" || calls[1] != "FUNCTION" || calls[2] != "FCALL" {
+		t.Fatalf("unexpected call sequence: %v", calls)
+	}
+}
+
+func TestFunctionScriptExecDoesNotRetryOnOtherErrors(t *testing.T) {
+	f := NewFunctionScript("myfunc", "body", false)
+	var calls int
+	do := func(ctx context.Context, args ...string) RedisResult {
+		calls++
+		return newResult(newErrorMessage("WRONGTYPE Operation against a key holding the wrong kind of value"), nil)
+	}
+	res := f.Exec(context.Background(), do, nil, nil)
+	if calls != 1 {
+		t.Fatalf("expected exactly one call, got %d", calls)
+	}
+	if err := res.Error(); err == nil {
+		t.Fatalf("expected the original error to be returned unchanged")
+	}
+}
+
+func TestScriptExecRetriesOnNoScript(t *testing.T) {
+	s := NewScript(1, "return 1")
+	var calls []string
+	do := func(ctx context.Context, args ...string) RedisResult {
+		calls = append(calls, args[0])
+		if args[0] == "EVALSHA" {
+			return newResult(newErrorMessage("NOSCRIPT No matching script"), nil)
+		}
+		return newResult(intMsg(1), nil)
+	}
+	res := s.Exec(context.Background(), do, []string{"k"}, nil)
+	if err := res.Error(); err != nil {
+		t.Fatalf("Exec returned error: %v", err)
+	}
+	if len(calls) != 2 || calls[0] != "EVALSHA" || calls[1] != "EVAL" {
+		t.Fatalf("unexpected call sequence: %v", calls)
+	}
+}